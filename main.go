@@ -1,66 +1,29 @@
 package main
 
 import (
-	"bufio"
-	"bytes"
-	"encoding/json"
+	"context"
 	"flag"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
-	"os/exec"
-	"regexp"
 	"strings"
 	"sync"
 	"time"
-)
-
-// Simple Discord message structure
-type DiscordMessage struct {
-	Content string `json:"content"`
-}
-
-type SubnetTracker struct {
-	sync.Mutex
-	attempts  map[string]int  // subnet -> attempts in last minute
-	blacklist map[string]bool // subnet -> is blacklisted
-	lastReset time.Time       // last time attempts were reset
-}
 
-func NewTracker() *SubnetTracker {
-	return &SubnetTracker{
-		attempts:  make(map[string]int),
-		blacklist: make(map[string]bool),
-		lastReset: time.Now(),
-	}
-}
-
-func setupLogging(logFile string) (*os.File, error) {
-	// Open log file with append mode, create if not exists
-	file, err := os.OpenFile(logFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-	if err != nil {
-		return nil, err
-	}
-
-	// Configure log package to write to file and include timestamp
-	log.SetOutput(file)
-	log.SetFlags(log.Ldate | log.Ltime)
-
-	return file, nil
-}
-
-func logEvent(file *os.File, format string, v ...interface{}) {
-	// Get current timestamp
-	timestamp := time.Now().Format("2006-01-02 15:04:05")
-
-	// Format the message
-	msg := fmt.Sprintf(format, v...)
-
-	// Write to file with timestamp
-	fmt.Fprintf(file, "[%s] %s\n", timestamp, msg)
-}
+	"github.com/Waffle-Host/better-monitor/pkg/api"
+	"github.com/Waffle-Host/better-monitor/pkg/block"
+	"github.com/Waffle-Host/better-monitor/pkg/config"
+	"github.com/Waffle-Host/better-monitor/pkg/geoip"
+	"github.com/Waffle-Host/better-monitor/pkg/logging"
+	"github.com/Waffle-Host/better-monitor/pkg/metrics"
+	"github.com/Waffle-Host/better-monitor/pkg/notify"
+	"github.com/Waffle-Host/better-monitor/pkg/ratelimit"
+	"github.com/Waffle-Host/better-monitor/pkg/watch"
+)
 
+// getSubnet collapses an IPv4 address down to its /24 for grouping
+// attempts from the same block of addresses.
 func getSubnet(ip string) string {
 	parts := strings.Split(ip, ".")
 	if len(parts) != 4 {
@@ -69,214 +32,311 @@ func getSubnet(ip string) string {
 	return strings.Join(parts[:3], ".") + ".0/24"
 }
 
-func getGeoIP(ip string) string {
-	if ip == "" {
-		return "Unknown"
-	}
+func main() {
+	configPath := flag.String("c", "config.json", "Path to config.json")
+	dryRun := flag.Bool("dry-run", false, "Log block/unblock actions instead of executing them")
+	logFormat := flag.String("log-format", "text", "Log output format: text or json")
+	logLevel := flag.String("log-level", "info", "Log level: debug, info, warn, or error")
+	defaultThreshold := flag.Int("threshold", 5, "Default attempts-per-window before a subnet is blocked, for watches that don't set their own")
+	defaultWindow := flag.Duration("window", 60*time.Second, "Default sliding window size, for watches that don't set their own")
+	geoipCityDB := flag.String("geoip-db", "", "Path to a MaxMind GeoLite2-City mmdb; falls back to ip-api.com if unset")
+	geoipASNDB := flag.String("geoip-asn-db", "", "Path to a MaxMind GeoLite2-ASN mmdb, adding ASN/org to events")
+	httpAddr := flag.String("http-addr", "", "Address to serve /metrics and the admin API on, e.g. :9090 (disabled if unset)")
+	flag.Parse()
 
-	resp, err := http.Get(fmt.Sprintf("http://ip-api.com/json/%s", ip))
+	logger, err := logging.New(*logFormat, *logLevel)
 	if err != nil {
-		return "Unknown"
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
 	}
-	defer resp.Body.Close()
+	slog.SetDefault(logger)
 
-	var result struct {
-		Country string `json:"country"`
-		City    string `json:"city"`
-		Status  string `json:"status"`
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		logger.Error("loading config", "error", err)
+		os.Exit(1)
+	}
+	if len(cfg.Watches) == 0 {
+		logger.Error("no watches configured")
+		os.Exit(1)
 	}
 
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return "Unknown"
+	onNotifierDrop := func(name string) {
+		metrics.NotifierFailuresTotal.WithLabelValues(name).Inc()
 	}
 
-	if result.Status != "success" {
-		return "Unknown"
+	notifiers := make([]notify.Notifier, 0, len(cfg.Notifiers))
+	for _, n := range cfg.Notifiers {
+		built, err := notify.Build(notify.Spec{Name: n.Name, Kind: n.Kind, Settings: n.Settings}, logger, onNotifierDrop)
+		if err != nil {
+			logger.Error("building notifier", "notifier", n.Name, "error", err)
+			os.Exit(1)
+		}
+		notifiers = append(notifiers, built)
 	}
+	dispatcher := notify.NewDispatcher(notifiers...)
+	dispatcher.SetLogger(logger)
+	dispatcher.SetFailureHook(onNotifierDrop)
 
-	if result.City != "" && result.Country != "" {
-		return fmt.Sprintf("%s, %s", result.City, result.Country)
+	geo, err := buildGeoResolver(*geoipCityDB, *geoipASNDB)
+	if err != nil {
+		logger.Error("building geoip resolver", "error", err)
+		os.Exit(1)
 	}
-	return "Unknown"
-}
+	defer geo.Close()
 
-func (t *SubnetTracker) isBlocked(subnet string) bool {
-	t.Lock()
-	defer t.Unlock()
-	return t.blacklist[subnet]
-}
+	ctx := context.Background()
 
-func (t *SubnetTracker) trackAttempt(subnet string, webhookURL string, logFile *os.File) {
-	t.Lock()
-	defer t.Unlock()
+	mgr, err := buildBlockManager(cfg.Block, *dryRun, dispatcher)
+	if err != nil {
+		logger.Error("building blocker", "error", err)
+		os.Exit(1)
+	}
+	if mgr != nil {
+		mgr.SetLogger(logger)
+		if err := mgr.Load(ctx); err != nil {
+			logger.Error("loading block state", "error", err)
+			os.Exit(1)
+		}
+		metrics.ActiveBlocks.Set(float64(len(mgr.List())))
+		go sweepExpired(ctx, mgr)
+	}
 
-	if t.blacklist[subnet] {
-		return
+	if *httpAddr != "" {
+		server := api.NewServer(mgr)
+		go func() {
+			if err := http.ListenAndServe(*httpAddr, server); err != nil {
+				logger.Error("admin http server exited", "error", err)
+			}
+		}()
+		logger.Info("admin http server listening", "addr", *httpAddr)
 	}
 
-	t.attempts[subnet]++
+	var wg sync.WaitGroup
 
-	// If more than 5 attempts in a minute, blacklist the subnet
-	if t.attempts[subnet] > 5 {
-		t.blacklist[subnet] = true
+	for _, wc := range cfg.Watches {
+		wc := wc
 
-		// Format block message
-		msg := fmt.Sprintf("🚫 Subnet `%s` blocked > %d attempts in the last minute",
-			subnet, t.attempts[subnet])
+		rules := make([]watch.Rule, 0, len(wc.Rules))
+		for _, r := range wc.Rules {
+			rule, err := watch.NewRule(r.Field, r.Pattern)
+			if err != nil {
+				logger.Error("watch rule", "watch", wc.Name, "error", err)
+				os.Exit(1)
+			}
+			rules = append(rules, rule)
+		}
 
-		fmt.Printf("🚫 Subnet %s blocked > %d attempts in the last minute\n",
-			subnet, t.attempts[subnet])
+		w := &watch.Watcher{
+			Name:    wc.Name,
+			Command: wc.Command,
+			File:    wc.File,
+			Rules:   rules,
+			Logger:  logger,
+		}
 
-		// Log the block
-		logEvent(logFile, "Block: %s", msg)
+		threshold := wc.Threshold.Attempts
+		if threshold <= 0 {
+			threshold = *defaultThreshold
+		}
+		window := *defaultWindow
+		if wc.Threshold.WindowS > 0 {
+			window = time.Duration(wc.Threshold.WindowS) * time.Second
+		}
 
-		// Send to Discord
-		webhook := DiscordMessage{Content: msg}
-		jsonData, _ := json.Marshal(webhook)
-		http.Post(webhookURL, "application/json", bytes.NewBuffer(jsonData))
-	}
-}
+		events := make(chan watch.Event, 64)
+		t := ratelimit.NewTracker(window)
+		go sweepTracker(ctx, t)
 
-func (t *SubnetTracker) cleanup() {
-	now := time.Now()
-	if now.Sub(t.lastReset) < time.Minute {
-		return
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := w.Run(ctx, events); err != nil {
+				logger.Warn("watch exited", "watch", wc.Name, "error", err)
+			}
+			close(events)
+		}()
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ev := range events {
+				handleEvent(ctx, wc, ev, t, threshold, dispatcher, mgr, geo, logger)
+			}
+		}()
 	}
 
-	t.Lock()
-	defer t.Unlock()
-
-	// Reset attempt counts every minute
-	t.attempts = make(map[string]int)
-	t.lastReset = now
+	wg.Wait()
 }
 
-func extractIP(line string) string {
-	// Try different patterns to extract IP
-	patterns := []string{
-		`from\s+(\d{1,3}\.\d{1,3}\.\d{1,3}\.\d{1,3})`,
-		`(\d{1,3}\.\d{1,3}\.\d{1,3}\.\d{1,3})\s+port`,
-		`for\s+(\d{1,3}\.\d{1,3}\.\d{1,3}\.\d{1,3})`,
-		`user.*?(\d{1,3}\.\d{1,3}\.\d{1,3}\.\d{1,3})`,
+// buildBlockManager constructs a block.Manager from cfg, or returns nil if
+// no blocker is configured.
+func buildBlockManager(cfg config.Block, dryRun bool, dispatcher *notify.Dispatcher) (*block.Manager, error) {
+	if cfg.Kind == "" {
+		return nil, nil
 	}
 
-	for _, pattern := range patterns {
-		re := regexp.MustCompile(pattern)
-		if matches := re.FindStringSubmatch(line); len(matches) > 1 {
-			return matches[1]
+	var blocker block.Blocker
+	switch cfg.Kind {
+	case "iptables":
+		blocker = &block.IPTables{}
+	case "ip6tables":
+		blocker = &block.IPTables{V6: true}
+	case "nftables":
+		family := cfg.NFTFamily
+		if family == "" {
+			family = "inet"
 		}
+		blocker = &block.NFTables{Family: family, Table: cfg.NFTTable, Chain: cfg.NFTChain}
+	case "ipset":
+		blocker = &block.IPSet{SetName: cfg.IPSetName}
+	default:
+		return nil, fmt.Errorf("unknown block kind %q", cfg.Kind)
 	}
-	return ""
-}
 
-func extractUsername(line string) string {
-	patterns := []string{
-		`for\s+user\s+(\w+)`,
-		`user\s+(\w+)`,
-		`for\s+(\w+)`,
+	duration := time.Duration(cfg.DurationS) * time.Second
+	if duration <= 0 {
+		duration = time.Hour
+	}
+	statePath := cfg.StatePath
+	if statePath == "" {
+		statePath = "blacklist.json"
 	}
 
-	for _, pattern := range patterns {
-		re := regexp.MustCompile(pattern)
-		if matches := re.FindStringSubmatch(line); len(matches) > 1 {
-			return matches[1]
+	onChange := func(subnet string, blocked bool) {
+		msg := notify.Message{Title: fmt.Sprintf("🚫 %s", subnet)}
+		if blocked {
+			msg.Body = "blocked"
+		} else {
+			msg.Title = fmt.Sprintf("✅ %s", subnet)
+			msg.Body = "unblocked"
 		}
+		dispatcher.Send(context.Background(), dispatcher.Names(), msg)
 	}
-	return "unknown"
-}
 
-func main() {
-	webhookURL := flag.String("webhook", "", "Discord webhook URL")
-	logPath := flag.String("log", "ssh_monitor.log", "Path to log file")
-	flag.Parse()
+	return block.NewManager(blocker, duration, statePath, dryRun, onChange), nil
+}
 
-	if *webhookURL == "" {
-		log.Fatal("Please provide a Discord webhook URL using -webhook flag")
+// buildGeoResolver constructs a geoip.Resolver from the configured mmdb
+// paths. It always returns a Resolver; without a city db its lookups fall
+// back to the ip-api.com HTTP provider.
+func buildGeoResolver(cityDB, asnDB string) (*geoip.Resolver, error) {
+	var opts []geoip.Option
+	if cityDB != "" {
+		opts = append(opts, geoip.WithCityDB(cityDB))
 	}
-
-	// Setup logging
-	logFile, err := setupLogging(*logPath)
-	if err != nil {
-		log.Fatal("Error setting up logging:", err)
+	if asnDB != "" {
+		opts = append(opts, geoip.WithASNDB(asnDB))
 	}
-	defer logFile.Close()
+	return geoip.NewResolver(4096, 8, opts...)
+}
 
-	tracker := NewTracker()
-	// Only show new logs with -n 0
-	cmd := exec.Command("journalctl", "-f", "-n", "0", "-u", "ssh.service", "--no-pager")
-	stdout, err := cmd.StdoutPipe()
-	if err != nil {
-		log.Fatal("Error creating stdout pipe:", err)
+// sweepExpired periodically lifts blocks whose duration has elapsed.
+func sweepExpired(ctx context.Context, mgr *block.Manager) {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	for range ticker.C {
+		mgr.SweepExpired(ctx)
+		metrics.ActiveBlocks.Set(float64(len(mgr.List())))
 	}
+}
 
-	if err := cmd.Start(); err != nil {
-		log.Fatal("Error starting journalctl:", err)
+// sweepTracker periodically garbage-collects subnets whose sliding windows
+// have gone empty, bounding a tracker's memory between bursts.
+func sweepTracker(ctx context.Context, t *ratelimit.Tracker) {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			t.Sweep()
+		}
 	}
+}
 
-	scanner := bufio.NewScanner(stdout)
-	startMsg := "🔒 SSH Monitor Started - Watching for suspicious activity..."
-	log.Println(startMsg)
-	logEvent(logFile, "%s", startMsg)
-
-	// Send start message to Discord
-	webhook := DiscordMessage{Content: startMsg}
-	jsonData, _ := json.Marshal(webhook)
-	http.Post(*webhookURL, "application/json", bytes.NewBuffer(jsonData))
+// handleEvent applies a watch's tracker/threshold to a parsed Event,
+// applies an active block once threshold is crossed, and fans the
+// resulting notifications out through dispatcher. GeoIP enrichment happens
+// asynchronously via geo.LookupAsync so a cache miss never stalls the
+// watch's event loop.
+func handleEvent(ctx context.Context, wc config.Watch, ev watch.Event, t *ratelimit.Tracker, threshold int, dispatcher *notify.Dispatcher, mgr *block.Manager, geo *geoip.Resolver, logger *slog.Logger) {
+	ip := ev.Fields["ip"]
+	if ip == "" {
+		return
+	}
 
-	for scanner.Scan() {
-		line := scanner.Text()
+	subnet := getSubnet(ip)
+	key := wc.Name + ":" + subnet
+	if mgr != nil && mgr.IsBlocked(subnet) {
+		logger.Debug("dropping event from blocked subnet", "watch", wc.Name, "subnet", subnet)
+		return
+	}
 
-		// Log raw SSH line
-		logEvent(logFile, "Raw: %s", line)
+	// A watch marks a line as a successful attempt by giving one of its
+	// rules the reserved field name "success" (e.g. matching sshd's
+	// "Accepted ..." lines); watches that don't configure one never see
+	// this, so every parsed event counts toward the threshold.
+	success := ev.Fields["success"] != ""
 
-		// Look for any SSH-related activity
-		if !strings.Contains(strings.ToLower(line), "ssh") {
-			continue
+	geo.LookupAsync(ip, func(loc geoip.Result, err error) {
+		location := loc.String()
+		if err != nil {
+			location = "Unknown"
 		}
 
-		ip := extractIP(line)
-		if ip == "" {
-			continue
+		logger.Info("event",
+			"watch", wc.Name,
+			"event_type", "activity",
+			"ip", ip,
+			"subnet", subnet,
+			"username", ev.Fields["username"],
+			"country", loc.Country,
+			"org", loc.Org,
+		)
+
+		msg := notify.Message{
+			Title:  fmt.Sprintf("🔍 %s activity from %s", wc.Name, location),
+			Body:   ev.Raw,
+			Fields: ev.Fields,
 		}
+		dispatcher.Send(ctx, wc.Notifiers, msg)
 
-		subnet := getSubnet(ip)
-		if !tracker.isBlocked(subnet) {
-			// Get location
-			location := getGeoIP(ip)
-			username := extractUsername(line)
-
-			var event string
-			if strings.Contains(line, "Accepted") {
-				// Successful login
-				event = fmt.Sprintf("✅ Successful login from %s (%s) as '%s'",
-					ip, location, username)
-				fmt.Printf("✅ Successful login from %s (%s) as '%s'\n",
-					ip, location, username)
-			} else {
-				// Other SSH activity
-				event = fmt.Sprintf("🔍 SSH activity from %s (%s) Subnet: %s",
-					ip, location, subnet)
-				fmt.Printf("🔍 SSH activity from %s (%s) Subnet: %s\n",
-					ip, location, subnet)
-			}
+		metrics.AttemptsTotal.WithLabelValues(subnet, loc.Country).Inc()
+		if username := ev.Fields["username"]; username != "" && success {
+			metrics.SuccessfulLoginsTotal.WithLabelValues(username, loc.Country).Inc()
+		}
+	})
 
-			// Log the event
-			logEvent(logFile, "Event: %s", event)
+	// Successful attempts don't count toward the abuse threshold - only
+	// failed ones should ever trip a block.
+	if success {
+		return
+	}
 
-			// Send to Discord
-			webhook := DiscordMessage{Content: event}
-			jsonData, _ := json.Marshal(webhook)
-			http.Post(*webhookURL, "application/json", bytes.NewBuffer(jsonData))
+	attempts := t.Record(key)
+	if attempts > threshold {
+		logger.Info("event",
+			"watch", wc.Name,
+			"event_type", "block",
+			"subnet", subnet,
+			"attempts", attempts,
+		)
+
+		blockMsg := notify.Message{
+			Title: fmt.Sprintf("🚫 %s blocked", key),
+			Body:  fmt.Sprintf("more than %d attempts in the configured window", threshold),
+		}
+		dispatcher.Send(ctx, wc.Notifiers, blockMsg)
 
-			// Only track failed attempts
-			if !strings.Contains(line, "Accepted") {
-				tracker.trackAttempt(subnet, *webhookURL, logFile)
+		if mgr != nil {
+			if err := mgr.Block(ctx, subnet); err != nil {
+				logger.Error("block failed", "subnet", subnet, "error", err)
+			} else {
+				metrics.BlocksTotal.Inc()
+				metrics.ActiveBlocks.Set(float64(len(mgr.List())))
 			}
-		} else {
-			// Log blocked attempts too
-			logEvent(logFile, "Blocked attempt from %s (subnet %s)", ip, subnet)
 		}
-
-		tracker.cleanup()
 	}
 }
@@ -0,0 +1,115 @@
+// Package api exposes an admin HTTP server: Prometheus metrics at
+// /metrics plus a small JSON API for inspecting and managing the active
+// blacklist without restarting the daemon.
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/Waffle-Host/better-monitor/pkg/block"
+)
+
+// Stats is the payload returned by GET /stats.
+type Stats struct {
+	ActiveBlocks int `json:"active_blocks"`
+}
+
+// Server is the admin HTTP server. It has no concept of watches or
+// notifiers - those stay in main - only the block manager it needs to
+// serve /blacklist and /stats.
+type Server struct {
+	mgr *block.Manager
+	mux *http.ServeMux
+}
+
+// NewServer builds a Server backed by mgr. mgr may be nil, in which case
+// the blacklist endpoints report an empty list and manual block/unblock
+// requests fail with 404.
+func NewServer(mgr *block.Manager) *Server {
+	s := &Server{mgr: mgr, mux: http.NewServeMux()}
+
+	s.mux.Handle("/metrics", promhttp.Handler())
+	s.mux.HandleFunc("/blacklist", s.handleBlacklist)
+	s.mux.HandleFunc("/blacklist/", s.handleBlacklistSubnet)
+	s.mux.HandleFunc("/stats", s.handleStats)
+
+	return s
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) handleBlacklist(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		if s.mgr == nil {
+			writeJSON(w, http.StatusOK, []block.Entry{})
+			return
+		}
+		writeJSON(w, http.StatusOK, s.mgr.List())
+
+	case http.MethodPost:
+		if s.mgr == nil {
+			http.Error(w, "no blocker configured", http.StatusNotFound)
+			return
+		}
+		var body struct {
+			Subnet string `json:"subnet"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Subnet == "" {
+			http.Error(w, "body must be {\"subnet\": \"...\"}", http.StatusBadRequest)
+			return
+		}
+		if err := s.mgr.Block(r.Context(), body.Subnet); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) handleBlacklistSubnet(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.mgr == nil {
+		http.Error(w, "no blocker configured", http.StatusNotFound)
+		return
+	}
+
+	subnet := strings.TrimPrefix(r.URL.Path, "/blacklist/")
+	if subnet == "" {
+		http.Error(w, "missing subnet", http.StatusBadRequest)
+		return
+	}
+
+	if err := s.mgr.Unblock(r.Context(), subnet); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
+	stats := Stats{}
+	if s.mgr != nil {
+		stats.ActiveBlocks = len(s.mgr.List())
+	}
+	writeJSON(w, http.StatusOK, stats)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
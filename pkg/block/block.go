@@ -0,0 +1,264 @@
+// Package block applies and lifts active network blocks against abusive
+// subnets, and keeps the resulting blacklist durable across restarts.
+package block
+
+import (
+	"container/heap"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// Blocker drops and restores traffic from a subnet using some underlying
+// firewall mechanism (iptables, nftables, ipset, ...).
+type Blocker interface {
+	Name() string
+	Block(ctx context.Context, subnet string) error
+	Unblock(ctx context.Context, subnet string) error
+}
+
+// entry is one active block, ordered by Expires for the expiry heap.
+type entry struct {
+	Subnet  string    `json:"subnet"`
+	Expires time.Time `json:"expires"`
+	index   int
+}
+
+type expiryHeap []*entry
+
+func (h expiryHeap) Len() int           { return len(h) }
+func (h expiryHeap) Less(i, j int) bool { return h[i].Expires.Before(h[j].Expires) }
+func (h expiryHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index, h[j].index = i, j
+}
+func (h *expiryHeap) Push(x any) {
+	e := x.(*entry)
+	e.index = len(*h)
+	*h = append(*h, e)
+}
+func (h *expiryHeap) Pop() any {
+	old := *h
+	n := len(old)
+	e := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+	return e
+}
+
+// OnChange is called whenever a subnet is blocked or unblocked, so callers
+// can announce the action to notifiers.
+type OnChange func(subnet string, blocked bool)
+
+// Manager tracks which subnets are currently blocked, persists that state
+// to disk, and unblocks subnets once their duration expires.
+type Manager struct {
+	blocker   Blocker
+	duration  time.Duration
+	statePath string
+	dryRun    bool
+	onChange  OnChange
+	logger    *slog.Logger
+
+	mu      sync.Mutex
+	entries map[string]*entry
+	expiry  expiryHeap
+}
+
+// NewManager builds a Manager that uses blocker to apply blocks lasting
+// duration, persisting its state to statePath. If dryRun is true, Block and
+// Unblock log what they would do instead of invoking blocker.
+func NewManager(blocker Blocker, duration time.Duration, statePath string, dryRun bool, onChange OnChange) *Manager {
+	return &Manager{
+		blocker:   blocker,
+		duration:  duration,
+		statePath: statePath,
+		dryRun:    dryRun,
+		onChange:  onChange,
+		logger:    slog.Default(),
+		entries:   make(map[string]*entry),
+	}
+}
+
+// SetLogger overrides the logger used for dry-run and persistence messages.
+func (m *Manager) SetLogger(logger *slog.Logger) {
+	m.logger = logger
+}
+
+// Load restores previously persisted blocks from statePath, re-applying
+// any that haven't yet expired and dropping (without re-blocking) any that
+// have. Re-applying relies on every Blocker's Block being idempotent (a
+// no-op if the underlying rule already exists), since on an ordinary
+// restart the firewall/ipset state from before the restart is usually
+// still in place.
+func (m *Manager) Load(ctx context.Context) error {
+	data, err := os.ReadFile(m.statePath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("block: read state: %w", err)
+	}
+
+	var saved []entry
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return fmt.Errorf("block: parse state: %w", err)
+	}
+
+	now := time.Now()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, e := range saved {
+		if e.Expires.Before(now) {
+			continue
+		}
+		if !m.dryRun {
+			if err := m.blocker.Block(ctx, e.Subnet); err != nil {
+				return fmt.Errorf("block: reapply %s: %w", e.Subnet, err)
+			}
+		}
+		ne := &entry{Subnet: e.Subnet, Expires: e.Expires}
+		m.entries[e.Subnet] = ne
+		heap.Push(&m.expiry, ne)
+	}
+	return nil
+}
+
+// IsBlocked reports whether subnet currently has an active block.
+func (m *Manager) IsBlocked(subnet string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, ok := m.entries[subnet]
+	return ok
+}
+
+// Entry is one active block as reported by List.
+type Entry struct {
+	Subnet  string    `json:"subnet"`
+	Expires time.Time `json:"expires"`
+}
+
+// List returns every currently active block.
+func (m *Manager) List() []Entry {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	out := make([]Entry, 0, len(m.entries))
+	for _, e := range m.entries {
+		out = append(out, Entry{Subnet: e.Subnet, Expires: e.Expires})
+	}
+	return out
+}
+
+// Block applies a block against subnet for the Manager's configured
+// duration. It is a no-op if subnet is already blocked. The blocker call
+// happens before any in-memory state is committed, so a failed block
+// leaves subnet unmarked and eligible for the caller to retry.
+func (m *Manager) Block(ctx context.Context, subnet string) error {
+	if m.IsBlocked(subnet) {
+		return nil
+	}
+
+	if m.dryRun {
+		m.logger.Info("dry-run block", "subnet", subnet, "blocker", m.blocker.Name())
+	} else if err := m.blocker.Block(ctx, subnet); err != nil {
+		return fmt.Errorf("block: %s: %w", subnet, err)
+	}
+
+	m.mu.Lock()
+	if _, ok := m.entries[subnet]; ok {
+		m.mu.Unlock()
+		return nil
+	}
+	e := &entry{Subnet: subnet, Expires: time.Now().Add(m.duration)}
+	m.entries[subnet] = e
+	heap.Push(&m.expiry, e)
+	m.mu.Unlock()
+
+	m.persist()
+	if m.onChange != nil {
+		m.onChange(subnet, true)
+	}
+	return nil
+}
+
+// Unblock lifts any active block against subnet. The entry is only removed
+// once the blocker confirms the underlying rule is gone, so a failed
+// unblock leaves subnet's state intact (and eligible to be swept again)
+// rather than claiming success it didn't achieve.
+func (m *Manager) Unblock(ctx context.Context, subnet string) error {
+	m.mu.Lock()
+	e, ok := m.entries[subnet]
+	m.mu.Unlock()
+	if !ok {
+		return nil
+	}
+
+	if m.dryRun {
+		m.logger.Info("dry-run unblock", "subnet", subnet, "blocker", m.blocker.Name())
+	} else if err := m.blocker.Unblock(ctx, subnet); err != nil {
+		return fmt.Errorf("unblock: %s: %w", subnet, err)
+	}
+
+	m.mu.Lock()
+	if cur, ok := m.entries[subnet]; ok && cur == e {
+		delete(m.entries, subnet)
+		heap.Remove(&m.expiry, cur.index)
+	}
+	m.mu.Unlock()
+
+	m.persist()
+	if m.onChange != nil {
+		m.onChange(subnet, false)
+	}
+	return nil
+}
+
+// SweepExpired unblocks every subnet whose duration has elapsed. Call this
+// periodically (e.g. from a ticker goroutine). A subnet whose Unblock call
+// fails is left in place rather than retried in a tight loop; it's picked
+// up again on the next sweep.
+func (m *Manager) SweepExpired(ctx context.Context) {
+	now := time.Now()
+
+	m.mu.Lock()
+	var subnets []string
+	for _, e := range m.expiry {
+		if !e.Expires.After(now) {
+			subnets = append(subnets, e.Subnet)
+		}
+	}
+	m.mu.Unlock()
+
+	// Unblock removes each entry (and its heap slot) itself once the
+	// blocker confirms success, so a single pass over this snapshot never
+	// revisits an entry - including ones left in place by a failed call.
+	for _, subnet := range subnets {
+		if err := m.Unblock(ctx, subnet); err != nil {
+			m.logger.Error("sweep unblock failed", "subnet", subnet, "error", err)
+		}
+	}
+}
+
+func (m *Manager) persist() {
+	m.mu.Lock()
+	saved := make([]entry, 0, len(m.entries))
+	for _, e := range m.entries {
+		saved = append(saved, entry{Subnet: e.Subnet, Expires: e.Expires})
+	}
+	m.mu.Unlock()
+
+	data, err := json.Marshal(saved)
+	if err != nil {
+		m.logger.Error("marshal block state failed", "error", err)
+		return
+	}
+	if err := os.WriteFile(m.statePath, data, 0644); err != nil {
+		m.logger.Error("write block state failed", "error", err)
+	}
+}
@@ -0,0 +1,189 @@
+package block
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// fakeBlocker lets tests control whether Block/Unblock succeed and records
+// every call it receives.
+type fakeBlocker struct {
+	failBlock   bool
+	failUnblock bool
+	blocked     []string
+	unblocked   []string
+}
+
+func (b *fakeBlocker) Name() string { return "fake" }
+
+func (b *fakeBlocker) Block(ctx context.Context, subnet string) error {
+	if b.failBlock {
+		return errors.New("block failed")
+	}
+	b.blocked = append(b.blocked, subnet)
+	return nil
+}
+
+func (b *fakeBlocker) Unblock(ctx context.Context, subnet string) error {
+	if b.failUnblock {
+		return errors.New("unblock failed")
+	}
+	b.unblocked = append(b.unblocked, subnet)
+	return nil
+}
+
+func newTestManager(t *testing.T, blocker Blocker, duration time.Duration) *Manager {
+	t.Helper()
+	statePath := filepath.Join(t.TempDir(), "blacklist.json")
+	return NewManager(blocker, duration, statePath, false, nil)
+}
+
+func TestManagerBlockSuccess(t *testing.T) {
+	fb := &fakeBlocker{}
+	m := newTestManager(t, fb, time.Hour)
+
+	if err := m.Block(context.Background(), "1.2.3.0/24"); err != nil {
+		t.Fatalf("Block() error = %v", err)
+	}
+	if !m.IsBlocked("1.2.3.0/24") {
+		t.Fatal("IsBlocked() = false after a successful Block()")
+	}
+	if len(fb.blocked) != 1 {
+		t.Fatalf("blocker.Block called %d times, want 1", len(fb.blocked))
+	}
+}
+
+func TestManagerBlockFailureLeavesNoState(t *testing.T) {
+	fb := &fakeBlocker{failBlock: true}
+	m := newTestManager(t, fb, time.Hour)
+
+	if err := m.Block(context.Background(), "1.2.3.0/24"); err == nil {
+		t.Fatal("Block() error = nil, want an error from the failing blocker")
+	}
+	if m.IsBlocked("1.2.3.0/24") {
+		t.Fatal("IsBlocked() = true after a failed Block(); state was committed anyway")
+	}
+	if len(m.List()) != 0 {
+		t.Fatalf("List() = %v, want empty after a failed Block()", m.List())
+	}
+}
+
+func TestManagerUnblockSuccess(t *testing.T) {
+	fb := &fakeBlocker{}
+	m := newTestManager(t, fb, time.Hour)
+
+	ctx := context.Background()
+	if err := m.Block(ctx, "1.2.3.0/24"); err != nil {
+		t.Fatalf("Block() error = %v", err)
+	}
+	if err := m.Unblock(ctx, "1.2.3.0/24"); err != nil {
+		t.Fatalf("Unblock() error = %v", err)
+	}
+	if m.IsBlocked("1.2.3.0/24") {
+		t.Fatal("IsBlocked() = true after a successful Unblock()")
+	}
+}
+
+func TestManagerUnblockFailureKeepsState(t *testing.T) {
+	fb := &fakeBlocker{failUnblock: true}
+	m := newTestManager(t, fb, time.Hour)
+
+	ctx := context.Background()
+	if err := m.Block(ctx, "1.2.3.0/24"); err != nil {
+		t.Fatalf("Block() error = %v", err)
+	}
+	if err := m.Unblock(ctx, "1.2.3.0/24"); err == nil {
+		t.Fatal("Unblock() error = nil, want an error from the failing blocker")
+	}
+	if !m.IsBlocked("1.2.3.0/24") {
+		t.Fatal("IsBlocked() = false after a failed Unblock(); state was dropped anyway")
+	}
+}
+
+func TestManagerSweepExpiredOnlyLiftsExpired(t *testing.T) {
+	fb := &fakeBlocker{}
+	m := newTestManager(t, fb, time.Hour)
+
+	ctx := context.Background()
+	m.mu.Lock()
+	live := &entry{Subnet: "1.1.1.0/24", Expires: time.Now().Add(time.Hour)}
+	expired := &entry{Subnet: "2.2.2.0/24", Expires: time.Now().Add(-time.Minute)}
+	m.entries[live.Subnet] = live
+	m.entries[expired.Subnet] = expired
+	heap.Push(&m.expiry, live)
+	heap.Push(&m.expiry, expired)
+	m.mu.Unlock()
+
+	m.SweepExpired(ctx)
+
+	if !m.IsBlocked("1.1.1.0/24") {
+		t.Fatal("SweepExpired() lifted a block that hadn't expired yet")
+	}
+	if m.IsBlocked("2.2.2.0/24") {
+		t.Fatal("SweepExpired() left an expired block in place")
+	}
+	if len(fb.unblocked) != 1 || fb.unblocked[0] != "2.2.2.0/24" {
+		t.Fatalf("blocker.Unblock calls = %v, want [2.2.2.0/24]", fb.unblocked)
+	}
+}
+
+func TestManagerSweepExpiredLeavesFailuresForNextSweep(t *testing.T) {
+	fb := &fakeBlocker{failUnblock: true}
+	m := newTestManager(t, fb, time.Hour)
+
+	ctx := context.Background()
+	m.mu.Lock()
+	expired := &entry{Subnet: "2.2.2.0/24", Expires: time.Now().Add(-time.Minute)}
+	m.entries[expired.Subnet] = expired
+	heap.Push(&m.expiry, expired)
+	m.mu.Unlock()
+
+	m.SweepExpired(ctx)
+
+	if !m.IsBlocked("2.2.2.0/24") {
+		t.Fatal("SweepExpired() dropped state for an unblock that failed")
+	}
+}
+
+func TestManagerLoadPersistAndReload(t *testing.T) {
+	fb := &fakeBlocker{}
+	statePath := filepath.Join(t.TempDir(), "blacklist.json")
+
+	m := NewManager(fb, time.Hour, statePath, false, nil)
+	if err := m.Block(context.Background(), "1.2.3.0/24"); err != nil {
+		t.Fatalf("Block() error = %v", err)
+	}
+
+	reloaded := NewManager(fb, time.Hour, statePath, false, nil)
+	if err := reloaded.Load(context.Background()); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if !reloaded.IsBlocked("1.2.3.0/24") {
+		t.Fatal("Load() didn't restore a persisted, unexpired block")
+	}
+}
+
+func TestManagerLoadSkipsExpiredEntries(t *testing.T) {
+	fb := &fakeBlocker{}
+	statePath := filepath.Join(t.TempDir(), "blacklist.json")
+
+	m := NewManager(fb, time.Hour, statePath, false, nil)
+	m.mu.Lock()
+	expired := &entry{Subnet: "2.2.2.0/24", Expires: time.Now().Add(-time.Minute)}
+	m.entries[expired.Subnet] = expired
+	heap.Push(&m.expiry, expired)
+	m.mu.Unlock()
+	m.persist()
+
+	reloaded := NewManager(fb, time.Hour, statePath, false, nil)
+	if err := reloaded.Load(context.Background()); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if reloaded.IsBlocked("2.2.2.0/24") {
+		t.Fatal("Load() reapplied an already-expired block")
+	}
+}
@@ -0,0 +1,23 @@
+package block
+
+import "context"
+
+// IPSet adds/removes subnets from a named ipset, expected to already be
+// referenced by a persistent iptables/nftables rule (e.g. `-m set
+// --match-set <SetName> src -j DROP`).
+type IPSet struct {
+	SetName string
+}
+
+func (b *IPSet) Name() string { return "ipset:" + b.SetName }
+
+// Block adds subnet to the set. -exist makes this idempotent: re-adding an
+// element that's already a member (e.g. on daemon restart, before the
+// kernel set has been cleared) succeeds instead of erroring.
+func (b *IPSet) Block(ctx context.Context, subnet string) error {
+	return run(ctx, "ipset", "add", b.SetName, subnet, "-exist")
+}
+
+func (b *IPSet) Unblock(ctx context.Context, subnet string) error {
+	return run(ctx, "ipset", "del", b.SetName, subnet)
+}
@@ -0,0 +1,57 @@
+package block
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+)
+
+// IPTables blocks subnets with `iptables -I INPUT -s <subnet> -j DROP`. Set
+// V6 to use ip6tables instead.
+type IPTables struct {
+	V6 bool
+}
+
+func (b *IPTables) binary() string {
+	if b.V6 {
+		return "ip6tables"
+	}
+	return "iptables"
+}
+
+func (b *IPTables) Name() string {
+	if b.V6 {
+		return "ip6tables"
+	}
+	return "iptables"
+}
+
+// Block inserts a DROP rule for subnet, unless one is already present (e.g.
+// on daemon restart, while the subnet is still within its block duration):
+// -C only checks a rule's existence (exiting zero if it is present) without
+// adding anything, so re-applying an already-active block is a no-op instead
+// of piling up a second identical rule.
+func (b *IPTables) Block(ctx context.Context, subnet string) error {
+	if b.exists(ctx, subnet) {
+		return nil
+	}
+	return run(ctx, b.binary(), "-I", "INPUT", "-s", subnet, "-j", "DROP")
+}
+
+func (b *IPTables) exists(ctx context.Context, subnet string) bool {
+	cmd := exec.CommandContext(ctx, b.binary(), "-C", "INPUT", "-s", subnet, "-j", "DROP")
+	return cmd.Run() == nil
+}
+
+func (b *IPTables) Unblock(ctx context.Context, subnet string) error {
+	return run(ctx, b.binary(), "-D", "INPUT", "-s", subnet, "-j", "DROP")
+}
+
+func run(ctx context.Context, name string, args ...string) error {
+	cmd := exec.CommandContext(ctx, name, args...)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s %v: %w: %s", name, args, err, out)
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package block
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// NFTables blocks subnets by adding a rule to a pre-existing table/chain
+// (e.g. created out-of-band with `nft add chain inet filter input`), and
+// lifts them by looking up that rule's handle and deleting it by handle -
+// the only rule-removal primitive nft actually offers.
+type NFTables struct {
+	Family string // e.g. "inet"
+	Table  string // e.g. "filter"
+	Chain  string // e.g. "input"
+}
+
+func (b *NFTables) Name() string { return "nftables" }
+
+// Block adds a drop rule for subnet, unless a matching one is already
+// present (e.g. on daemon restart, while the subnet is still within its
+// block duration) - nft has no "add if absent" primitive, so this checks
+// via the same handle lookup Unblock uses before inserting a duplicate.
+func (b *NFTables) Block(ctx context.Context, subnet string) error {
+	handle, err := b.findHandle(ctx, subnet)
+	if err != nil {
+		return err
+	}
+	if handle != "" {
+		return nil
+	}
+	return run(ctx, "nft", "add", "rule", b.Family, b.Table, b.Chain, "ip", "saddr", subnet, "drop")
+}
+
+// Unblock looks up the handle nft assigned the blocking rule and deletes
+// it by handle; nft has no "delete rule matching expression" primitive.
+// It's a no-op if no matching rule is found.
+func (b *NFTables) Unblock(ctx context.Context, subnet string) error {
+	handle, err := b.findHandle(ctx, subnet)
+	if err != nil {
+		return err
+	}
+	if handle == "" {
+		return nil
+	}
+	return run(ctx, "nft", "delete", "rule", b.Family, b.Table, b.Chain, "handle", handle)
+}
+
+// findHandle lists the chain with handles annotated (`-a`) and returns the
+// handle of the rule dropping subnet, or "" if none is found.
+func (b *NFTables) findHandle(ctx context.Context, subnet string) (string, error) {
+	cmd := exec.CommandContext(ctx, "nft", "-a", "list", "chain", b.Family, b.Table, b.Chain)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("nft -a list chain %s %s %s: %w: %s", b.Family, b.Table, b.Chain, err, out)
+	}
+
+	needle := fmt.Sprintf("ip saddr %s drop", subnet)
+	for _, line := range strings.Split(string(out), "\n") {
+		if !strings.Contains(line, needle) {
+			continue
+		}
+		idx := strings.LastIndex(line, "handle ")
+		if idx < 0 {
+			continue
+		}
+		return strings.TrimSpace(line[idx+len("handle "):]), nil
+	}
+	return "", nil
+}
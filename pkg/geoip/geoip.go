@@ -0,0 +1,270 @@
+// Package geoip resolves IP addresses to a location, preferring a local
+// MaxMind GeoLite2 database over the old synchronous ip-api.com call so
+// log processing never blocks on - or leaks IPs to - a third party.
+package geoip
+
+import (
+	"container/list"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+
+	"github.com/oschwald/geoip2-golang"
+)
+
+// Result is what a lookup resolves an IP to. ASN/Org are only populated
+// when a GeoLite2-ASN database was configured.
+type Result struct {
+	City    string
+	Country string
+	ASN     uint
+	Org     string
+}
+
+func (r Result) String() string {
+	if r.City == "" && r.Country == "" {
+		return "Unknown"
+	}
+	if r.City == "" {
+		return r.Country
+	}
+	return fmt.Sprintf("%s, %s", r.City, r.Country)
+}
+
+// Resolver looks up IPs via a local mmdb when configured, falling back to
+// the ip-api.com HTTP API otherwise. Results are cached, and the HTTP
+// fallback runs through a bounded worker pool so a flood of cache misses
+// can't pile up outstanding requests or block callers.
+type Resolver struct {
+	city *geoip2.Reader
+	asn  *geoip2.Reader
+
+	cache *lru
+
+	httpSem chan struct{}
+	client  *http.Client
+}
+
+// Option configures a Resolver.
+type Option func(*Resolver) error
+
+// WithCityDB opens a GeoLite2-City (or GeoLite2-Country) mmdb at path.
+func WithCityDB(path string) Option {
+	return func(r *Resolver) error {
+		db, err := geoip2.Open(path)
+		if err != nil {
+			return fmt.Errorf("geoip: open city db: %w", err)
+		}
+		r.city = db
+		return nil
+	}
+}
+
+// WithASNDB opens a GeoLite2-ASN mmdb at path, adding ASN/org to results.
+func WithASNDB(path string) Option {
+	return func(r *Resolver) error {
+		db, err := geoip2.Open(path)
+		if err != nil {
+			return fmt.Errorf("geoip: open asn db: %w", err)
+		}
+		r.asn = db
+		return nil
+	}
+}
+
+// NewResolver builds a Resolver with the given cache size (entries) and
+// HTTP fallback concurrency (how many ip-api.com lookups may be in flight
+// at once).
+func NewResolver(cacheSize, httpConcurrency int, opts ...Option) (*Resolver, error) {
+	r := &Resolver{
+		cache:   newLRU(cacheSize),
+		httpSem: make(chan struct{}, httpConcurrency),
+		client:  &http.Client{},
+	}
+	for _, opt := range opts {
+		if err := opt(r); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// Close releases any open mmdb handles.
+func (r *Resolver) Close() {
+	if r.city != nil {
+		r.city.Close()
+	}
+	if r.asn != nil {
+		r.asn.Close()
+	}
+}
+
+// Lookup resolves ip, preferring the local mmdb(s) when configured. If
+// only the HTTP fallback is available, the call is synchronous and is not
+// bounded by httpSem; use LookupAsync to avoid blocking the caller.
+func (r *Resolver) Lookup(ip string) (Result, error) {
+	if ip == "" {
+		return Result{}, fmt.Errorf("geoip: empty ip")
+	}
+
+	if cached, ok := r.cache.get(ip); ok {
+		return cached, nil
+	}
+
+	var (
+		result Result
+		err    error
+	)
+	if r.city != nil {
+		result, err = r.lookupMMDB(ip)
+	} else {
+		result, err = r.lookupHTTP(ip)
+		if err == nil && r.asn != nil {
+			if parsed := net.ParseIP(ip); parsed != nil {
+				if asn, asnErr := r.asn.ASN(parsed); asnErr == nil {
+					result.ASN = asn.AutonomousSystemNumber
+					result.Org = asn.AutonomousSystemOrganization
+				}
+			}
+		}
+	}
+	if err != nil {
+		return Result{}, err
+	}
+
+	r.cache.put(ip, result)
+	return result, nil
+}
+
+// LookupAsync resolves ip without blocking the caller, invoking cb with
+// the result once it's ready. Cache hits call cb synchronously, as do
+// misses when a city mmdb is configured; HTTP fallback misses are handed to
+// a worker pool bounded by the Resolver's httpConcurrency.
+func (r *Resolver) LookupAsync(ip string, cb func(Result, error)) {
+	if cached, ok := r.cache.get(ip); ok {
+		cb(cached, nil)
+		return
+	}
+
+	if r.city != nil {
+		// Local mmdb lookups are cheap enough to do inline.
+		cb(r.Lookup(ip))
+		return
+	}
+
+	go func() {
+		r.httpSem <- struct{}{}
+		defer func() { <-r.httpSem }()
+		cb(r.Lookup(ip))
+	}()
+}
+
+func (r *Resolver) lookupMMDB(ip string) (Result, error) {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return Result{}, fmt.Errorf("geoip: invalid ip %q", ip)
+	}
+
+	city, err := r.city.City(parsed)
+	if err != nil {
+		return Result{}, fmt.Errorf("geoip: city lookup %s: %w", ip, err)
+	}
+
+	result := Result{
+		City:    city.City.Names["en"],
+		Country: city.Country.Names["en"],
+	}
+
+	if r.asn != nil {
+		asn, err := r.asn.ASN(parsed)
+		if err == nil {
+			result.ASN = asn.AutonomousSystemNumber
+			result.Org = asn.AutonomousSystemOrganization
+		}
+	}
+
+	return result, nil
+}
+
+func (r *Resolver) lookupHTTP(ip string) (Result, error) {
+	resp, err := r.client.Get(fmt.Sprintf("http://ip-api.com/json/%s", ip))
+	if err != nil {
+		return Result{}, fmt.Errorf("geoip: http lookup %s: %w", ip, err)
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		Country string `json:"country"`
+		City    string `json:"city"`
+		Status  string `json:"status"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return Result{}, fmt.Errorf("geoip: decode %s: %w", ip, err)
+	}
+	if body.Status != "success" {
+		return Result{}, fmt.Errorf("geoip: lookup %s: provider status %q", ip, body.Status)
+	}
+
+	return Result{City: body.City, Country: body.Country}, nil
+}
+
+// lru is a small fixed-size cache keyed by IP, evicting least-recently-used
+// entries once full.
+type lru struct {
+	mu       sync.Mutex
+	size     int
+	ll       *list.List
+	elements map[string]*list.Element
+}
+
+type lruEntry struct {
+	key   string
+	value Result
+}
+
+func newLRU(size int) *lru {
+	if size <= 0 {
+		size = 1024
+	}
+	return &lru{
+		size:     size,
+		ll:       list.New(),
+		elements: make(map[string]*list.Element, size),
+	}
+}
+
+func (c *lru) get(key string) (Result, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.elements[key]
+	if !ok {
+		return Result{}, false
+	}
+	c.ll.MoveToFront(el)
+	return el.Value.(*lruEntry).value, true
+}
+
+func (c *lru) put(key string, value Result) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.elements[key]; ok {
+		el.Value.(*lruEntry).value = value
+		c.ll.MoveToFront(el)
+		return
+	}
+
+	el := c.ll.PushFront(&lruEntry{key: key, value: value})
+	c.elements[key] = el
+
+	if c.ll.Len() > c.size {
+		oldest := c.ll.Back()
+		if oldest != nil {
+			c.ll.Remove(oldest)
+			delete(c.elements, oldest.Value.(*lruEntry).key)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+// Package logging builds the structured logger shared across
+// better-monitor, replacing the old ad-hoc log.Printf/logEvent mix with
+// log/slog so events can be filtered and shipped to Loki/ELK.
+package logging
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+)
+
+// New builds a slog.Logger writing to stderr in the requested format
+// ("text" or "json") at the requested level ("debug", "info", "warn", or
+// "error"). Text is the default for interactive use; json suits log
+// shippers.
+func New(format, level string) (*slog.Logger, error) {
+	lvl, err := parseLevel(level)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := &slog.HandlerOptions{Level: lvl}
+
+	var handler slog.Handler
+	switch format {
+	case "", "text":
+		handler = slog.NewTextHandler(os.Stderr, opts)
+	case "json":
+		handler = slog.NewJSONHandler(os.Stderr, opts)
+	default:
+		return nil, fmt.Errorf("logging: unknown format %q (want text or json)", format)
+	}
+
+	return slog.New(handler), nil
+}
+
+func parseLevel(level string) (slog.Level, error) {
+	switch level {
+	case "", "info":
+		return slog.LevelInfo, nil
+	case "debug":
+		return slog.LevelDebug, nil
+	case "warn":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	default:
+		return 0, fmt.Errorf("logging: unknown level %q (want debug, info, warn, or error)", level)
+	}
+}
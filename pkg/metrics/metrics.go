@@ -0,0 +1,50 @@
+// Package metrics declares the Prometheus metrics better-monitor exposes
+// on its admin HTTP server, so operators can wire it into existing
+// Grafana dashboards alongside everything else it's watching.
+package metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var (
+	// AttemptsTotal counts every parsed activity event, by subnet and
+	// country.
+	AttemptsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "ssh_attempts_total",
+		Help: "Total parsed SSH activity events, by subnet and country.",
+	}, []string{"subnet", "country"})
+
+	// BlocksTotal counts every subnet blocked since startup.
+	BlocksTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "ssh_blocks_total",
+		Help: "Total subnets blocked since startup.",
+	})
+
+	// SuccessfulLoginsTotal counts accepted logins, by username and
+	// country.
+	SuccessfulLoginsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "ssh_successful_logins_total",
+		Help: "Total successful logins, by username and country.",
+	}, []string{"username", "country"})
+
+	// ActiveBlocks reports how many subnets are currently blocked.
+	ActiveBlocks = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "ssh_active_blocks",
+		Help: "Number of subnets currently blocked.",
+	})
+
+	// NotifierFailuresTotal counts delivery failures, by notifier name.
+	NotifierFailuresTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "notifier_failures_total",
+		Help: "Total notifier delivery failures, by notifier name.",
+	}, []string{"notifier"})
+)
+
+func init() {
+	prometheus.MustRegister(
+		AttemptsTotal,
+		BlocksTotal,
+		SuccessfulLoginsTotal,
+		ActiveBlocks,
+		NotifierFailuresTotal,
+	)
+}
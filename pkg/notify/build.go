@@ -0,0 +1,71 @@
+package notify
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"log/syslog"
+	"os"
+)
+
+// Spec is the subset of config.Notifier that Build needs, duplicated here
+// rather than importing the config package to keep notify free of a
+// dependency on it.
+type Spec struct {
+	Name     string
+	Kind     string
+	Settings json.RawMessage
+}
+
+// Build constructs the Notifier described by spec. logger receives the
+// notifier's internal retry/backoff diagnostics; onDrop is called (with
+// the notifier's name) whenever a queued notifier gives up on a message
+// after exhausting its retries.
+func Build(spec Spec, logger *slog.Logger, onDrop func(notifier string)) (Notifier, error) {
+	switch spec.Kind {
+	case "discord":
+		var s struct {
+			WebhookURL string `json:"webhook_url"`
+		}
+		if err := json.Unmarshal(spec.Settings, &s); err != nil {
+			return nil, fmt.Errorf("notify: discord %q: %w", spec.Name, err)
+		}
+		return NewDiscord(spec.Name, s.WebhookURL, logger, onDrop), nil
+
+	case "slack":
+		var s struct {
+			WebhookURL string `json:"webhook_url"`
+		}
+		if err := json.Unmarshal(spec.Settings, &s); err != nil {
+			return nil, fmt.Errorf("notify: slack %q: %w", spec.Name, err)
+		}
+		return NewSlack(spec.Name, s.WebhookURL, logger, onDrop), nil
+
+	case "webhook":
+		var s struct {
+			URL string `json:"url"`
+		}
+		if err := json.Unmarshal(spec.Settings, &s); err != nil {
+			return nil, fmt.Errorf("notify: webhook %q: %w", spec.Name, err)
+		}
+		return NewWebhook(spec.Name, s.URL, logger, onDrop), nil
+
+	case "syslog":
+		var s struct {
+			Tag string `json:"tag"`
+		}
+		if err := json.Unmarshal(spec.Settings, &s); err != nil {
+			return nil, fmt.Errorf("notify: syslog %q: %w", spec.Name, err)
+		}
+		if s.Tag == "" {
+			s.Tag = "better-monitor"
+		}
+		return NewSyslog(spec.Name, syslog.LOG_WARNING, s.Tag)
+
+	case "stdout":
+		return NewStdout(spec.Name, os.Stdout), nil
+
+	default:
+		return nil, errUnsupportedKind(spec.Kind)
+	}
+}
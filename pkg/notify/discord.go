@@ -0,0 +1,147 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	discordMaxRetries     = 5
+	discordQueueCapacity  = 256
+	discordBatchThreshold = 10
+	discordMaxEmbeds      = 10
+)
+
+// Discord posts messages to a Discord incoming webhook. Posts are
+// serialized through a single worker so Discord's 30 req/min per-webhook
+// limit is respected: bursts beyond discordBatchThreshold queued messages
+// are coalesced into one multi-embed post, 429s are honored via their
+// Retry-After header, and 5xx/network errors are retried with exponential
+// backoff before the message is dropped and reported.
+type Discord struct {
+	name       string
+	webhookURL string
+	client     *http.Client
+	queue      *asyncQueue
+}
+
+type discordPayload struct {
+	Content string         `json:"content,omitempty"`
+	Embeds  []discordEmbed `json:"embeds,omitempty"`
+}
+
+type discordEmbed struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+}
+
+// NewDiscord builds a Discord notifier named name, posting to webhookURL.
+// onDrop is called (with the notifier's name) whenever a message is
+// dropped after exhausting its retries or because the queue is full.
+func NewDiscord(name, webhookURL string, logger *slog.Logger, onDrop func(notifier string)) *Discord {
+	d := &Discord{
+		name:       name,
+		webhookURL: webhookURL,
+		client:     &http.Client{},
+	}
+	d.queue = newAsyncQueue(name, discordQueueCapacity, discordBatchThreshold, discordMaxRetries, logger, onDrop)
+	d.queue.run(context.Background(), d.deliver)
+	return d
+}
+
+func (d *Discord) Name() string { return d.name }
+
+// Notify enqueues msg for delivery and returns immediately; delivery
+// failures are retried in the background and reported via onDrop rather
+// than returned here.
+func (d *Discord) Notify(_ context.Context, msg Message) error {
+	d.queue.enqueue(msg)
+	return nil
+}
+
+func (d *Discord) deliver(ctx context.Context, batch []Message) (time.Duration, error) {
+	payload, dropped := batchToPayload(batch)
+	for i := 0; i < dropped; i++ {
+		d.queue.warnDrop("discord: batch exceeds max embeds, dropping message", "notifier", d.name)
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return 0, fmt.Errorf("discord: marshal: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("discord: build request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("discord: post: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return retryAfter(resp.Header), fmt.Errorf("discord: rate limited")
+	}
+	if resp.StatusCode >= 500 {
+		return 0, fmt.Errorf("discord: server error %s", resp.Status)
+	}
+	if resp.StatusCode >= 300 {
+		// Client errors (bad webhook, malformed payload, ...) won't
+		// succeed on retry, so don't ask deliverWithRetry to keep trying -
+		// but do surface the loss rather than silently eating the batch.
+		d.queue.warnDrop("discord: webhook rejected payload, dropping batch", "notifier", d.name, "status", resp.Status)
+		return 0, nil
+	}
+
+	if remaining := resp.Header.Get("X-RateLimit-Remaining"); remaining == "0" {
+		time.Sleep(retryAfter(resp.Header))
+	}
+
+	return 0, nil
+}
+
+// batchToPayload renders a single message as plain content, matching the
+// original format, and coalesces multiple messages into one post using
+// Discord embeds so their structure survives batching. Discord caps a
+// single post at discordMaxEmbeds embeds, so batches larger than that are
+// truncated; dropped reports how many messages didn't make it in, for the
+// caller to surface.
+func batchToPayload(batch []Message) (payload discordPayload, dropped int) {
+	if len(batch) == 1 {
+		msg := batch[0]
+		return discordPayload{Content: fmt.Sprintf("%s\n%s", msg.Title, msg.Body)}, 0
+	}
+
+	embeds := make([]discordEmbed, 0, len(batch))
+	for _, msg := range batch {
+		if len(embeds) == discordMaxEmbeds {
+			break
+		}
+		embeds = append(embeds, discordEmbed{Title: msg.Title, Description: msg.Body})
+	}
+
+	return discordPayload{
+		Content: fmt.Sprintf("%d events", len(batch)),
+		Embeds:  embeds,
+	}, len(batch) - len(embeds)
+}
+
+// retryAfter parses Discord's Retry-After header (seconds, possibly
+// fractional) into a Duration, defaulting to one second if absent or
+// unparseable.
+func retryAfter(h http.Header) time.Duration {
+	secs, err := strconv.ParseFloat(h.Get("Retry-After"), 64)
+	if err != nil || secs <= 0 {
+		return time.Second
+	}
+	return time.Duration(secs * float64(time.Second))
+}
@@ -0,0 +1,87 @@
+// Package notify fans a stream of messages out to one or more configured
+// notification sinks (Discord, Slack, a generic webhook, syslog, stdout).
+package notify
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+)
+
+// Message is what a Watch reports to its configured notifiers - a block,
+// an unblock, a suspicious event, or anything else worth surfacing.
+type Message struct {
+	Title  string
+	Body   string
+	Fields map[string]string
+}
+
+// Notifier delivers a Message to one destination. Implementations must be
+// safe for concurrent use.
+type Notifier interface {
+	Name() string
+	Notify(ctx context.Context, msg Message) error
+}
+
+// Dispatcher fans a Message out to a named subset of registered Notifiers.
+type Dispatcher struct {
+	notifiers map[string]Notifier
+	logger    *slog.Logger
+	onFailure func(notifier string)
+}
+
+// NewDispatcher builds a Dispatcher from the given notifiers, logging
+// delivery failures to the default slog logger. Use SetLogger to override.
+func NewDispatcher(notifiers ...Notifier) *Dispatcher {
+	d := &Dispatcher{notifiers: make(map[string]Notifier, len(notifiers)), logger: slog.Default()}
+	for _, n := range notifiers {
+		d.notifiers[n.Name()] = n
+	}
+	return d
+}
+
+// SetLogger overrides the logger used to report delivery failures.
+func (d *Dispatcher) SetLogger(logger *slog.Logger) {
+	d.logger = logger
+}
+
+// SetFailureHook registers a callback invoked with the notifier's name
+// every time Send fails to deliver to it, letting callers feed the
+// failure into metrics without notify depending on a metrics package.
+func (d *Dispatcher) SetFailureHook(hook func(notifier string)) {
+	d.onFailure = hook
+}
+
+// Names returns the names of every registered notifier, useful for
+// broadcasting messages that aren't tied to a specific watch.
+func (d *Dispatcher) Names() []string {
+	names := make([]string, 0, len(d.notifiers))
+	for name := range d.notifiers {
+		names = append(names, name)
+	}
+	return names
+}
+
+// Send delivers msg to each named notifier, logging (but not returning) any
+// per-notifier failure so one broken sink can't block the others.
+func (d *Dispatcher) Send(ctx context.Context, names []string, msg Message) {
+	for _, name := range names {
+		n, ok := d.notifiers[name]
+		if !ok {
+			d.logger.Warn("unknown notifier", "notifier", name)
+			continue
+		}
+		if err := n.Notify(ctx, msg); err != nil {
+			d.logger.Warn("notifier failed", "notifier", name, "error", err)
+			if d.onFailure != nil {
+				d.onFailure(name)
+			}
+		}
+	}
+}
+
+// errUnsupportedKind is returned by Build for a notifier kind with no
+// matching constructor.
+func errUnsupportedKind(kind string) error {
+	return fmt.Errorf("notify: unsupported notifier kind %q", kind)
+}
@@ -0,0 +1,114 @@
+package notify
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// deliverFunc sends a batch of Messages (usually just one) to a sink. If it
+// returns a non-zero retryAfter, the caller waits that long before the next
+// attempt - set from a 429 response's Retry-After header, for example.
+type deliverFunc func(ctx context.Context, batch []Message) (retryAfter time.Duration, err error)
+
+// asyncQueue serializes delivery to one sink through a single worker
+// goroutine, retrying failed sends with backoff and coalescing bursts into
+// batches once the backlog passes batchThreshold. It's shared by the
+// Discord and generic webhook notifiers so both get the same
+// retry/batch/rate-limit behavior.
+type asyncQueue struct {
+	name           string
+	ch             chan Message
+	batchThreshold int
+	maxRetries     int
+	logger         *slog.Logger
+	onDrop         func(notifier string)
+}
+
+func newAsyncQueue(name string, capacity, batchThreshold, maxRetries int, logger *slog.Logger, onDrop func(notifier string)) *asyncQueue {
+	return &asyncQueue{
+		name:           name,
+		ch:             make(chan Message, capacity),
+		batchThreshold: batchThreshold,
+		maxRetries:     maxRetries,
+		logger:         logger,
+		onDrop:         onDrop,
+	}
+}
+
+// enqueue hands msg to the worker, dropping it (and reporting the drop) if
+// the queue is already full.
+func (q *asyncQueue) enqueue(msg Message) {
+	select {
+	case q.ch <- msg:
+	default:
+		q.logger.Warn("notifier queue full, dropping message", "notifier", q.name)
+		q.drop()
+	}
+}
+
+// run starts the worker goroutine, which hands each message (or, once the
+// backlog exceeds batchThreshold, a coalesced batch) to deliver.
+func (q *asyncQueue) run(ctx context.Context, deliver deliverFunc) {
+	go func() {
+		for {
+			var batch []Message
+			select {
+			case <-ctx.Done():
+				return
+			case msg := <-q.ch:
+				batch = append(batch, msg)
+			}
+
+			for len(q.ch) > q.batchThreshold {
+				select {
+				case msg := <-q.ch:
+					batch = append(batch, msg)
+				default:
+				}
+			}
+
+			q.deliverWithRetry(ctx, deliver, batch)
+		}
+	}()
+}
+
+func (q *asyncQueue) deliverWithRetry(ctx context.Context, deliver deliverFunc, batch []Message) {
+	backoff := time.Second
+	for attempt := 0; attempt <= q.maxRetries; attempt++ {
+		retryAfter, err := deliver(ctx, batch)
+		if err == nil {
+			return
+		}
+
+		q.logger.Warn("notifier delivery failed", "notifier", q.name, "attempt", attempt, "error", err)
+
+		wait := backoff
+		if retryAfter > 0 {
+			wait = retryAfter
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+		backoff *= 2
+	}
+
+	q.logger.Warn("notifier dropped message after max retries", "notifier", q.name, "retries", q.maxRetries)
+	q.drop()
+}
+
+func (q *asyncQueue) drop() {
+	if q.onDrop != nil {
+		q.onDrop(q.name)
+	}
+}
+
+// warnDrop logs msg as a warning and reports a dropped message, for sinks
+// that need to give up on a message outside the normal retry-exhaustion
+// path (e.g. a payload that can't fit the sink's format).
+func (q *asyncQueue) warnDrop(msg string, args ...any) {
+	q.logger.Warn(msg, args...)
+	q.drop()
+}
@@ -0,0 +1,49 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/syslog"
+)
+
+// Stdout writes messages to an io.Writer (os.Stdout in normal use). Handy
+// for local testing of a config without wiring up a real sink.
+type Stdout struct {
+	name string
+	w    io.Writer
+}
+
+// NewStdout builds a Stdout notifier that writes to w.
+func NewStdout(name string, w io.Writer) *Stdout {
+	return &Stdout{name: name, w: w}
+}
+
+func (s *Stdout) Name() string { return s.name }
+
+func (s *Stdout) Notify(_ context.Context, msg Message) error {
+	_, err := fmt.Fprintf(s.w, "%s: %s\n", msg.Title, msg.Body)
+	return err
+}
+
+// Syslog forwards messages to the local syslog daemon.
+type Syslog struct {
+	name   string
+	writer *syslog.Writer
+}
+
+// NewSyslog builds a Syslog notifier tagged with the given priority/tag.
+func NewSyslog(name string, priority syslog.Priority, tag string) (*Syslog, error) {
+	w, err := syslog.New(priority, tag)
+	if err != nil {
+		return nil, fmt.Errorf("syslog: dial: %w", err)
+	}
+	return &Syslog{name: name, writer: w}, nil
+}
+
+func (s *Syslog) Name() string { return s.name }
+
+func (s *Syslog) Notify(_ context.Context, msg Message) error {
+	_, err := s.writer.Write([]byte(fmt.Sprintf("%s: %s", msg.Title, msg.Body)))
+	return err
+}
@@ -0,0 +1,147 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+const (
+	webhookMaxRetries     = 5
+	webhookQueueCapacity  = 256
+	webhookBatchThreshold = 10
+)
+
+// Webhook posts a JSON-encoded Message (or, under load, a batch of them)
+// to an arbitrary HTTP endpoint. It's the escape hatch for sinks
+// (Slack-compatible or otherwise) that don't warrant a dedicated
+// implementation, queued and retried the same way as Discord.
+type Webhook struct {
+	name   string
+	url    string
+	client *http.Client
+	queue  *asyncQueue
+}
+
+// NewWebhook builds a generic JSON webhook notifier.
+func NewWebhook(name, url string, logger *slog.Logger, onDrop func(notifier string)) *Webhook {
+	w := &Webhook{name: name, url: url, client: &http.Client{}}
+	w.queue = newAsyncQueue(name, webhookQueueCapacity, webhookBatchThreshold, webhookMaxRetries, logger, onDrop)
+	w.queue.run(context.Background(), w.deliver)
+	return w
+}
+
+func (w *Webhook) Name() string { return w.name }
+
+func (w *Webhook) Notify(_ context.Context, msg Message) error {
+	w.queue.enqueue(msg)
+	return nil
+}
+
+func (w *Webhook) deliver(ctx context.Context, batch []Message) (time.Duration, error) {
+	var body []byte
+	var err error
+	if len(batch) == 1 {
+		body, err = json.Marshal(batch[0])
+	} else {
+		body, err = json.Marshal(batch)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("webhook: marshal: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("webhook: build request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("webhook: post: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return retryAfter(resp.Header), fmt.Errorf("webhook: rate limited")
+	}
+	if resp.StatusCode >= 500 {
+		return 0, fmt.Errorf("webhook: server error %s", resp.Status)
+	}
+	if resp.StatusCode >= 300 {
+		// Client errors (bad URL, malformed payload, ...) won't succeed on
+		// retry, so don't ask deliverWithRetry to keep trying - but do
+		// surface the loss rather than silently eating the batch.
+		w.queue.warnDrop("webhook: endpoint rejected payload, dropping batch", "notifier", w.name, "status", resp.Status)
+	}
+	return 0, nil
+}
+
+// Slack is a Webhook configured for Slack's "text" payload convention.
+// Slack's incoming webhooks accept {"text": "..."} rather than arbitrary
+// JSON, so it gets its own thin wrapper, queued and retried the same way.
+type Slack struct {
+	name   string
+	url    string
+	client *http.Client
+	queue  *asyncQueue
+}
+
+// NewSlack builds a Slack incoming-webhook notifier.
+func NewSlack(name, url string, logger *slog.Logger, onDrop func(notifier string)) *Slack {
+	s := &Slack{name: name, url: url, client: &http.Client{}}
+	s.queue = newAsyncQueue(name, webhookQueueCapacity, webhookBatchThreshold, webhookMaxRetries, logger, onDrop)
+	s.queue.run(context.Background(), s.deliver)
+	return s
+}
+
+func (s *Slack) Name() string { return s.name }
+
+func (s *Slack) Notify(_ context.Context, msg Message) error {
+	s.queue.enqueue(msg)
+	return nil
+}
+
+func (s *Slack) deliver(ctx context.Context, batch []Message) (time.Duration, error) {
+	text := batch[0].Title + "\n" + batch[0].Body
+	for _, msg := range batch[1:] {
+		text += fmt.Sprintf("\n\n%s\n%s", msg.Title, msg.Body)
+	}
+
+	body, err := json.Marshal(struct {
+		Text string `json:"text"`
+	}{Text: text})
+	if err != nil {
+		return 0, fmt.Errorf("slack: marshal: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("slack: build request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("slack: post: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return retryAfter(resp.Header), fmt.Errorf("slack: rate limited")
+	}
+	if resp.StatusCode >= 500 {
+		return 0, fmt.Errorf("slack: server error %s", resp.Status)
+	}
+	if resp.StatusCode >= 300 {
+		// Client errors (bad webhook, malformed payload, ...) won't
+		// succeed on retry, so don't ask deliverWithRetry to keep trying -
+		// but do surface the loss rather than silently eating the batch.
+		s.queue.warnDrop("slack: webhook rejected payload, dropping batch", "notifier", s.name, "status", resp.Status)
+	}
+	return 0, nil
+}
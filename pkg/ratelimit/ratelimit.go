@@ -0,0 +1,76 @@
+// Package ratelimit tracks how many attempts a key (e.g. a watch+subnet
+// pair) has made within a trailing time window, so callers can decide when
+// it's crossed an abuse threshold. Unlike a fixed-minute counter, this
+// gives correct behavior across minute boundaries: 5 attempts at 0:59 and
+// 5 more at 1:00 are seen as 10 attempts in two seconds, not two separate
+// bursts of 5.
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// Tracker counts attempts per key using a sliding window. Each key holds a
+// deque of attempt timestamps; Record drops entries older than
+// now-window before appending the new attempt.
+type Tracker struct {
+	window time.Duration
+
+	mu       sync.Mutex
+	attempts map[string][]time.Time
+}
+
+// NewTracker builds a Tracker that considers attempts within the trailing
+// window.
+func NewTracker(window time.Duration) *Tracker {
+	return &Tracker{
+		window:   window,
+		attempts: make(map[string][]time.Time),
+	}
+}
+
+// Record registers a new attempt for key and returns how many attempts
+// key has made within the trailing window, including this one.
+func (t *Tracker) Record(key string) int {
+	now := time.Now()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	deque := trim(t.attempts[key], now, t.window)
+	deque = append(deque, now)
+	t.attempts[key] = deque
+	return len(deque)
+}
+
+// Sweep drops keys whose deques have gone empty (every attempt has aged
+// out of the window), bounding memory for subnets that are no longer
+// active. Call this periodically from a background goroutine.
+func (t *Tracker) Sweep() {
+	now := time.Now()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for key, deque := range t.attempts {
+		deque = trim(deque, now, t.window)
+		if len(deque) == 0 {
+			delete(t.attempts, key)
+			continue
+		}
+		t.attempts[key] = deque
+	}
+}
+
+// trim drops entries older than now-window from the front of deque.
+// Entries are always appended in increasing time order, so the stale
+// prefix can be found with a single forward scan.
+func trim(deque []time.Time, now time.Time, window time.Duration) []time.Time {
+	cutoff := now.Add(-window)
+	i := 0
+	for i < len(deque) && deque[i].Before(cutoff) {
+		i++
+	}
+	return deque[i:]
+}
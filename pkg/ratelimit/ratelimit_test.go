@@ -0,0 +1,78 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTrim(t *testing.T) {
+	now := time.Now()
+	deque := []time.Time{
+		now.Add(-90 * time.Second),
+		now.Add(-45 * time.Second),
+		now.Add(-10 * time.Second),
+	}
+
+	got := trim(deque, now, 60*time.Second)
+	if len(got) != 2 {
+		t.Fatalf("trim: got %d entries, want 2", len(got))
+	}
+	if !got[0].Equal(deque[1]) || !got[1].Equal(deque[2]) {
+		t.Fatalf("trim: kept the wrong entries: %v", got)
+	}
+}
+
+func TestTrackerRecordWithinWindow(t *testing.T) {
+	tr := NewTracker(time.Minute)
+
+	for i, want := range []int{1, 2, 3} {
+		if got := tr.Record("k"); got != want {
+			t.Fatalf("attempt %d: Record() = %d, want %d", i, got, want)
+		}
+	}
+}
+
+func TestTrackerRecordIsPerKey(t *testing.T) {
+	tr := NewTracker(time.Minute)
+
+	tr.Record("a")
+	tr.Record("a")
+	if got := tr.Record("b"); got != 1 {
+		t.Fatalf("Record(b) = %d, want 1 (independent of key a)", got)
+	}
+}
+
+func TestTrackerRecordExpiresOldAttempts(t *testing.T) {
+	tr := NewTracker(10 * time.Millisecond)
+
+	tr.Record("k")
+	tr.Record("k")
+	time.Sleep(20 * time.Millisecond)
+
+	if got := tr.Record("k"); got != 1 {
+		t.Fatalf("Record() after window expiry = %d, want 1", got)
+	}
+}
+
+func TestTrackerSweepDropsEmptyKeys(t *testing.T) {
+	tr := NewTracker(10 * time.Millisecond)
+
+	tr.Record("k")
+	time.Sleep(20 * time.Millisecond)
+	tr.Sweep()
+
+	if _, ok := tr.attempts["k"]; ok {
+		t.Fatalf("Sweep() left an empty deque behind for key %q", "k")
+	}
+}
+
+func TestTrackerSweepKeepsLiveKeys(t *testing.T) {
+	tr := NewTracker(time.Minute)
+
+	tr.Record("k")
+	tr.Sweep()
+
+	if _, ok := tr.attempts["k"]; !ok {
+		t.Fatalf("Sweep() dropped a key still within its window")
+	}
+}
@@ -0,0 +1,99 @@
+// Package watch tails a log source - a long-running command or a file -
+// and turns each line into a structured Event using a set of configured
+// regex rules.
+package watch
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log/slog"
+	"os/exec"
+	"regexp"
+	"time"
+)
+
+// Event is a single parsed line from a Watch, carrying whatever fields its
+// rules managed to extract plus the raw source line for anything that
+// needs to fall back to it (e.g. logging).
+type Event struct {
+	Watch  string
+	Raw    string
+	Fields map[string]string
+	Time   time.Time
+}
+
+// Rule extracts a named field from a line via regex. The first capture
+// group becomes the field's value; rules that don't match are skipped.
+type Rule struct {
+	Field string
+	Regex *regexp.Regexp
+}
+
+// NewRule compiles pattern and binds it to field.
+func NewRule(field, pattern string) (Rule, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return Rule{}, fmt.Errorf("watch: compile rule %q: %w", field, err)
+	}
+	return Rule{Field: field, Regex: re}, nil
+}
+
+// Watcher is a single configured log source.
+type Watcher struct {
+	Name    string
+	Command []string // e.g. []string{"journalctl", "-f", "-u", "ssh.service"}
+	File    string   // alternative to Command: tail -F this path
+	Rules   []Rule
+	Logger  *slog.Logger // if set, logs each raw line at debug level
+}
+
+// Run tails the configured source until ctx is cancelled, sending a parsed
+// Event for every line on events. Run blocks and only returns once the
+// underlying command exits or ctx is done.
+func (w *Watcher) Run(ctx context.Context, events chan<- Event) error {
+	args := w.Command
+	if len(args) == 0 {
+		if w.File == "" {
+			return fmt.Errorf("watch %q: no command or file configured", w.Name)
+		}
+		args = []string{"tail", "-F", w.File}
+	}
+
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("watch %q: stdout pipe: %w", w.Name, err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("watch %q: start %v: %w", w.Name, args, err)
+	}
+
+	scanner := bufio.NewScanner(stdout)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if w.Logger != nil {
+			w.Logger.Debug("raw line", "watch", w.Name, "line", line)
+		}
+		events <- w.parse(line)
+	}
+
+	return cmd.Wait()
+}
+
+func (w *Watcher) parse(line string) Event {
+	fields := make(map[string]string, len(w.Rules))
+	for _, rule := range w.Rules {
+		if m := rule.Regex.FindStringSubmatch(line); len(m) > 1 {
+			fields[rule.Field] = m[1]
+		}
+	}
+
+	return Event{
+		Watch:  w.Name,
+		Raw:    line,
+		Fields: fields,
+		Time:   time.Now(),
+	}
+}